@@ -0,0 +1,90 @@
+// Command batch-consumer is an alternative to cmd/consumer that processes
+// tickets in batches via kafka.BatchHandler instead of one at a time,
+// trading a little latency for higher throughput.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"ticket-processor/internal/config"
+	"ticket-processor/internal/kafka"
+	"ticket-processor/internal/kafka/codec"
+	"ticket-processor/internal/metrics"
+	"ticket-processor/internal/models"
+)
+
+func processBatch(ctx context.Context, tickets []*models.Ticket) error {
+	log.Printf("Processing batch of %d tickets", len(tickets))
+
+	// Process the batch
+	// TODO: Add your business logic here
+
+	return nil
+}
+
+func main() {
+	// Initialize configuration
+	cfg := config.NewConfig()
+
+	// Initialize metrics
+	m := metrics.NewMetrics("ticket_processor_batch")
+
+	// Create consumer with auto-commit disabled; BatchHandler drives
+	// commits itself on its own ticker.
+	consumerConfig := &kafka.ConsumerConfig{
+		Codec:      codec.JSONCodec{},
+		AutoCommit: false,
+	}
+	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, cfg.GroupID, cfg.TopicPatterns, consumerConfig)
+	if err != nil {
+		log.Fatalf("Failed to create consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	// Create DLQ producer for batches that exhaust their retry budget
+	dlqProducer, err := kafka.NewDLQProducer(cfg.KafkaBrokers, cfg.DLQTopic)
+	if err != nil {
+		log.Fatalf("Failed to create DLQ producer: %v", err)
+	}
+	defer dlqProducer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle graceful shutdown
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	// Start consuming. wg is accounted for per-session by BatchHandler's
+	// Setup/Cleanup, not here, since Consume may drive several internally
+	// across topic-discovery rejoins.
+	wg := &sync.WaitGroup{}
+
+	handler := kafka.NewBatchHandler(m, wg, dlqProducer, kafka.NewBatchConfig(), kafka.NewRetryConfig(), consumer.Codec(), processBatch)
+
+	go func() {
+		for {
+			if err := consumer.Consume(ctx, handler, cfg.TopicDiscoveryInterval); err != nil {
+				log.Printf("Error from consumer: %v", err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			handler.Reset()
+		}
+	}()
+
+	<-handler.Ready()
+	log.Println("Batch consumer is ready")
+
+	// Wait for shutdown signal
+	<-signals
+	log.Println("Shutting down...")
+
+	cancel()
+	wg.Wait()
+}
@@ -9,35 +9,17 @@ import (
 	"syscall"
 	"ticket-processor/internal/config"
 	"ticket-processor/internal/kafka"
-
-	"github.com/IBM/sarama"
+	"ticket-processor/internal/kafka/codec"
+	"ticket-processor/internal/metrics"
+	"ticket-processor/internal/models"
 )
 
-type ConsumerHandler struct {
-	ready chan bool
-	wg    *sync.WaitGroup
-}
+func processTicket(ctx context.Context, ticket *models.Ticket) error {
+	log.Printf("Message received: ticket_id=%s order_id=%d", ticket.ID, ticket.OrderID)
 
-func (h *ConsumerHandler) Setup(sarama.ConsumerGroupSession) error {
-	close(h.ready)
-	return nil
-}
+	// Process the message
+	// TODO: Add your business logic here
 
-func (h *ConsumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
-	h.wg.Done()
-	return nil
-}
-
-func (h *ConsumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		log.Printf("Message received: topic=%s partition=%d offset=%d\n",
-			message.Topic, message.Partition, message.Offset)
-
-		// Process the message
-		// TODO: Add your business logic here
-
-		session.MarkMessage(message, "")
-	}
 	return nil
 }
 
@@ -45,11 +27,27 @@ func main() {
 	// Initialize configuration
 	cfg := config.NewConfig()
 
-	// Create consumer
-	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, cfg.GroupID, cfg.Topic)
+	// Initialize metrics
+	m := metrics.NewMetrics("ticket_processor")
+
+	// Create consumer, subscribed to every topic matching one of the
+	// configured literal names and/or regex patterns
+	consumerConfig := &kafka.ConsumerConfig{
+		Codec:      codec.JSONCodec{},
+		AutoCommit: true,
+	}
+	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, cfg.GroupID, cfg.TopicPatterns, consumerConfig)
 	if err != nil {
 		log.Fatalf("Failed to create consumer: %v", err)
 	}
+	defer consumer.Close()
+
+	// Create DLQ producer for un-processable messages
+	dlqProducer, err := kafka.NewDLQProducer(cfg.KafkaBrokers, cfg.DLQTopic)
+	if err != nil {
+		log.Fatalf("Failed to create DLQ producer: %v", err)
+	}
+	defer dlqProducer.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -58,28 +56,34 @@ func main() {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start consuming
+	// Start consuming. wg is accounted for per-session by Handler's
+	// Setup/Cleanup, not here, since Consume may drive several internally
+	// across topic-discovery rejoins.
 	wg := &sync.WaitGroup{}
-	wg.Add(1)
 
-	handler := &ConsumerHandler{
-		ready: make(chan bool),
-		wg:    wg,
-	}
+	registry := kafka.NewHandlerRegistry()
+	registry.Register(cfg.Topic, kafka.TopicHandler{
+		New: func() interface{} { return new(models.Ticket) },
+		Process: func(ctx context.Context, payload interface{}) error {
+			return processTicket(ctx, payload.(*models.Ticket))
+		},
+	})
+
+	handler := kafka.NewHandler(m, wg, dlqProducer, kafka.NewRetryConfig(), consumer.Codec(), registry)
 
 	go func() {
 		for {
-			if err := consumer.consumer.Consume(ctx, []string{cfg.Topic}, handler); err != nil {
+			if err := consumer.Consume(ctx, handler, cfg.TopicDiscoveryInterval); err != nil {
 				log.Printf("Error from consumer: %v", err)
 			}
 			if ctx.Err() != nil {
 				return
 			}
-			handler.ready = make(chan bool)
+			handler.Reset()
 		}
 	}()
 
-	<-handler.ready
+	<-handler.Ready()
 	log.Println("Consumer is ready")
 
 	// Wait for shutdown signal
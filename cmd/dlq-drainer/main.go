@@ -0,0 +1,157 @@
+// Command dlq-drainer consumes the dead-letter topic and republishes each
+// message back onto the main topic after a fixed delay, giving transient
+// failures time to clear before the message is retried.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"ticket-processor/internal/config"
+	"ticket-processor/internal/kafka"
+	"ticket-processor/internal/kafka/codec"
+	"ticket-processor/internal/metrics"
+	"ticket-processor/internal/models"
+
+	"github.com/IBM/sarama"
+)
+
+type drainHandler struct {
+	ready    chan bool
+	wg       *sync.WaitGroup
+	producer *kafka.Producer
+	codec    codec.Codec
+	delay    time.Duration
+}
+
+// Ready signals once Setup has run for the current session.
+func (h *drainHandler) Ready() <-chan bool {
+	return h.ready
+}
+
+// Reset replaces the ready channel, for callers that re-invoke Consume in a
+// loop across rebalances.
+func (h *drainHandler) Reset() {
+	h.ready = make(chan bool)
+}
+
+// Setup accounts for this session on wg (balanced by the matching Done in
+// Cleanup) so callers don't have to guess how many Setup/Cleanup cycles a
+// single Consume call will drive internally.
+func (h *drainHandler) Setup(sarama.ConsumerGroupSession) error {
+	h.wg.Add(1)
+	close(h.ready)
+	return nil
+}
+
+func (h *drainHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	h.wg.Done()
+	return nil
+}
+
+func (h *drainHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		wait := h.delay - time.Since(dlqTimestamp(message))
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-session.Context().Done():
+				return nil
+			}
+		}
+
+		var ticket models.Ticket
+		if err := h.codec.Unmarshal(message.Value, &ticket); err != nil {
+			log.Printf("Skipping unparsable DLQ message at offset %d: %v", message.Offset, err)
+			session.MarkMessage(message, "")
+			continue
+		}
+
+		if err := h.producer.SendTicket(&ticket); err != nil {
+			log.Printf("Failed to redrive DLQ message at offset %d: %v", message.Offset, err)
+			continue
+		}
+
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
+
+// dlqTimestamp returns when message was published to the DLQ, from its
+// kafka.DLQTimestampHeader header. If the header is missing or unparsable
+// (e.g. a message DLQ'd before this header existed), it returns the current
+// time so the message still waits out the full delay rather than being
+// redriven immediately.
+func dlqTimestamp(message *sarama.ConsumerMessage) time.Time {
+	for _, header := range message.Headers {
+		if string(header.Key) == kafka.DLQTimestampHeader {
+			if t, err := time.Parse(time.RFC3339Nano, string(header.Value)); err == nil {
+				return t
+			}
+			break
+		}
+	}
+	return time.Now()
+}
+
+func main() {
+	cfg := config.NewConfig()
+	m := metrics.NewMetrics("ticket_processor_dlq_drainer")
+
+	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, cfg.DLQDrainGroupID, []string{cfg.DLQTopic}, kafka.NewConsumerConfig())
+	if err != nil {
+		log.Fatalf("Failed to create DLQ consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	producer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.Topic, kafka.NewProducerConfig(), m)
+	if err != nil {
+		log.Fatalf("Failed to create producer: %v", err)
+	}
+	defer producer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	// wg is accounted for per-session by drainHandler's Setup/Cleanup, not
+	// here, since Consume may drive several internally across
+	// topic-discovery rejoins.
+	wg := &sync.WaitGroup{}
+
+	handler := &drainHandler{
+		ready:    make(chan bool),
+		wg:       wg,
+		producer: producer,
+		codec:    consumer.Codec(),
+		delay:    cfg.DLQDrainDelay,
+	}
+
+	go func() {
+		for {
+			if err := consumer.Consume(ctx, handler, cfg.TopicDiscoveryInterval); err != nil {
+				log.Printf("Error from DLQ consumer: %v", err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			handler.Reset()
+		}
+	}()
+
+	<-handler.Ready()
+	log.Println("DLQ drainer is ready")
+
+	<-signals
+	log.Println("Shutting down DLQ drainer...")
+
+	cancel()
+	wg.Wait()
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"ticket-processor/internal/config"
 	"ticket-processor/internal/kafka"
+	"ticket-processor/internal/kafka/codec"
 	"ticket-processor/internal/metrics"
 	"ticket-processor/internal/models"
 	"ticket-processor/internal/monitoring"
@@ -17,6 +19,11 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxBufferedTickets caps how many tickets are held in memory while the
+// breaker is open when BufferOnBreakerOpen is enabled, to avoid unbounded
+// growth during a prolonged outage.
+const maxBufferedTickets = 1000
+
 func main() {
 	// Initialize logger
 	logger, _ := zap.NewProduction()
@@ -37,12 +44,25 @@ func main() {
 	}()
 
 	// Create producer
-	producer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.Topic)
+	producerConfig := &kafka.ProducerConfig{
+		Codec:            codec.JSONCodec{},
+		Compression:      cfg.KafkaCompression,
+		CompressionLevel: cfg.KafkaCompressionLevel,
+		Breaker: &kafka.BreakerConfig{
+			ErrorThreshold:   cfg.BreakerErrorThreshold,
+			SuccessThreshold: cfg.BreakerSuccessThreshold,
+			Timeout:          cfg.BreakerTimeout,
+		},
+	}
+	producer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.Topic, producerConfig, metrics)
 	if err != nil {
 		logger.Fatal("Failed to create producer", zap.Error(err))
 	}
 	defer producer.Close()
 
+	// Tickets buffered while the breaker is open, replayed once it closes.
+	var bufferedTickets []*models.Ticket
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -74,11 +94,31 @@ func main() {
 				Data:      fmt.Sprintf("Ticket data %d", ticketCounter),
 			}
 
+			// Replay the oldest ticket buffered from a previous open breaker
+			// (one per tick) before sending the new ticket. The new ticket is
+			// not held back while older ones remain buffered, so order is
+			// only partly preserved.
+			if len(bufferedTickets) > 0 {
+				replay := bufferedTickets[0]
+				if err := producer.SendTicket(replay); err == nil {
+					bufferedTickets = bufferedTickets[1:]
+				}
+			}
+
 			if err := producer.SendTicket(ticket); err != nil {
 				logger.Error("Failed to send ticket",
 					zap.Error(err),
 					zap.String("ticket_id", ticket.ID),
 					zap.Int64("order_id", ticket.OrderID))
+
+				if errors.Is(err, kafka.ErrBreakerOpen) {
+					if cfg.BufferOnBreakerOpen && len(bufferedTickets) < maxBufferedTickets {
+						bufferedTickets = append(bufferedTickets, ticket)
+					} else {
+						logger.Warn("Dropping ticket while breaker is open",
+							zap.String("ticket_id", ticket.ID))
+					}
+				}
 				continue
 			}
 
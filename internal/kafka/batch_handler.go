@@ -0,0 +1,252 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ticket-processor/internal/kafka/codec"
+	"ticket-processor/internal/metrics"
+	"ticket-processor/internal/models"
+
+	"github.com/IBM/sarama"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// BatchConfig controls how BatchHandler buffers messages before invoking
+// its batch processor, and how often it commits offsets.
+type BatchConfig struct {
+	BatchSize      int
+	BatchTimeout   time.Duration
+	CommitInterval time.Duration
+}
+
+func NewBatchConfig() *BatchConfig {
+	return &BatchConfig{
+		BatchSize:      100,
+		BatchTimeout:   5 * time.Second,
+		CommitInterval: time.Second,
+	}
+}
+
+// BatchHandler buffers up to BatchSize messages, or BatchTimeout's worth of
+// waiting, whichever comes first, and hands them to process as a single
+// slice. Only the last message of each batch is marked, once the batch has
+// been processed or routed to the DLQ, relying on Sarama's contiguous-offset
+// semantics to cover the rest.
+//
+// Callers must disable Sarama's own auto-commit
+// (config.Consumer.Offsets.AutoCommit.Enable = false); BatchHandler drives
+// session.Commit() itself on a CommitInterval ticker, decoupling commit
+// frequency from batch size.
+type BatchHandler struct {
+	ready       chan bool
+	metrics     *metrics.Metrics
+	wg          *sync.WaitGroup
+	dlq         *DLQProducer
+	batchConfig *BatchConfig
+	retryConfig *RetryConfig
+	codec       codec.Codec
+	process     func(ctx context.Context, tickets []*models.Ticket) error
+}
+
+// NewBatchHandler builds a BatchHandler. dlq receives the whole batch,
+// message by message (mirroring Handler's DLQ pipeline), when process
+// keeps failing past retryConfig's budget. With a nil dlq, a batch that
+// exhausts its retries is treated like a failed DLQ publish: it is left
+// unmarked and ConsumeClaim returns an error.
+func NewBatchHandler(m *metrics.Metrics, wg *sync.WaitGroup, dlq *DLQProducer, batchConfig *BatchConfig, retryConfig *RetryConfig, c codec.Codec, process func(ctx context.Context, tickets []*models.Ticket) error) *BatchHandler {
+	if batchConfig == nil {
+		batchConfig = NewBatchConfig()
+	}
+	if retryConfig == nil {
+		retryConfig = NewRetryConfig()
+	}
+	if c == nil {
+		c = codec.JSONCodec{}
+	}
+	return &BatchHandler{
+		ready:       make(chan bool),
+		metrics:     m,
+		wg:          wg,
+		dlq:         dlq,
+		batchConfig: batchConfig,
+		retryConfig: retryConfig,
+		codec:       c,
+		process:     process,
+	}
+}
+
+// Ready signals once Setup has run for the current session.
+func (h *BatchHandler) Ready() <-chan bool {
+	return h.ready
+}
+
+// Reset replaces the ready channel, for callers that re-invoke Consume in a
+// loop across rebalances.
+func (h *BatchHandler) Reset() {
+	h.ready = make(chan bool)
+}
+
+// Setup accounts for this session on wg (balanced by the matching Done in
+// Cleanup) so callers don't have to guess how many Setup/Cleanup cycles a
+// single Consume call will drive internally.
+func (h *BatchHandler) Setup(session sarama.ConsumerGroupSession) error {
+	h.wg.Add(1)
+	for topic, partitions := range session.Claims() {
+		log.Printf("Assigned partitions: topic=%s partitions=%v", topic, partitions)
+	}
+	close(h.ready)
+	return nil
+}
+
+func (h *BatchHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	for topic, partitions := range session.Claims() {
+		log.Printf("Revoked partitions: topic=%s partitions=%v", topic, partitions)
+	}
+	h.wg.Done()
+	return nil
+}
+
+func (h *BatchHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batchTimer := time.NewTicker(h.batchConfig.BatchTimeout)
+	defer batchTimer.Stop()
+
+	commitTicker := time.NewTicker(h.batchConfig.CommitInterval)
+	defer commitTicker.Stop()
+
+	var tickets []*models.Ticket
+	var messages []*sarama.ConsumerMessage
+	var lastMessage *sarama.ConsumerMessage
+
+	// flush hands the buffered batch to process. On success, or once a
+	// failing batch has been fully routed to the DLQ, the last message is
+	// marked so the partition keeps flowing. If the DLQ publish itself
+	// fails, the batch is left unmarked and flush returns an error,
+	// stopping this claim so the offset is never advanced past a batch
+	// that was neither processed nor preserved.
+	flush := func() error {
+		if len(tickets) == 0 {
+			return nil
+		}
+
+		if attempts, err := h.processWithRetry(session.Context(), tickets); err != nil {
+			h.metrics.ErrorsCount.Inc()
+			log.Printf("Batch of %d tickets failed after exhausting retries, routing to DLQ: %v", len(tickets), err)
+			if !h.sendBatchToDLQ(messages, err, attempts) {
+				return fmt.Errorf("batch_handler: failed to DLQ batch of %d tickets, halting partition: %w", len(tickets), err)
+			}
+		} else {
+			h.metrics.MessagesConsumed.Add(float64(len(tickets)))
+		}
+
+		session.MarkMessage(lastMessage, "")
+		tickets = nil
+		messages = nil
+		lastMessage = nil
+		batchTimer.Reset(h.batchConfig.BatchTimeout)
+		return nil
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return flush()
+			}
+
+			var ticket models.Ticket
+			if err := h.codec.Unmarshal(message.Value, &ticket); err != nil {
+				h.metrics.ErrorsCount.Inc()
+				if !h.sendToDLQ(message, err, 1) {
+					log.Printf("Failed to DLQ unparsable message topic=%s partition=%d offset=%d, leaving offset uncommitted: %v", message.Topic, message.Partition, message.Offset, err)
+					return fmt.Errorf("batch_handler: failed to DLQ unparsable message at offset %d, halting partition: %w", message.Offset, err)
+				}
+				if len(tickets) == 0 {
+					session.MarkMessage(message, "")
+				} else {
+					// Leave the mark to the next flush so the offset doesn't
+					// pass the tickets still buffered ahead of this message.
+					lastMessage = message
+				}
+				continue
+			}
+
+			tickets = append(tickets, &ticket)
+			messages = append(messages, message)
+			lastMessage = message
+
+			if len(tickets) >= h.batchConfig.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-batchTimer.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-commitTicker.C:
+			session.Commit()
+		}
+	}
+}
+
+// sendBatchToDLQ republishes every message in the batch to the dead-letter
+// topic, tagged with procErr and the number of attempts made on the batch,
+// and reports whether all of them made it through.
+func (h *BatchHandler) sendBatchToDLQ(messages []*sarama.ConsumerMessage, procErr error, attempts int) bool {
+	for _, message := range messages {
+		if !h.sendToDLQ(message, procErr, attempts) {
+			return false
+		}
+	}
+	return true
+}
+
+// sendToDLQ republishes message to the dead-letter topic and reports
+// whether the publish succeeded (mirroring Handler.sendToDLQ).
+func (h *BatchHandler) sendToDLQ(message *sarama.ConsumerMessage, procErr error, attempts int) bool {
+	if h.dlq == nil {
+		return false
+	}
+	if err := h.dlq.Send(message, procErr, attempts); err != nil {
+		h.metrics.ErrorsCount.Inc()
+		return false
+	}
+	h.metrics.MessagesDLQ.Inc()
+	return true
+}
+
+// processWithRetry invokes the batch processor, retrying the whole batch
+// in-process per retryConfig while it keeps failing. It returns the number
+// of attempts made along with the last error.
+func (h *BatchHandler) processWithRetry(ctx context.Context, tickets []*models.Ticket) (int, error) {
+	if h.process == nil {
+		return 0, nil
+	}
+
+	b := h.retryConfig.CreateBackOff()
+	var lastErr error
+	var attempts int
+	for attempt := uint64(1); attempt <= h.retryConfig.MaxRetries; attempt++ {
+		attempts = int(attempt)
+		lastErr = h.process(ctx, tickets)
+		if lastErr == nil {
+			return attempts, nil
+		}
+		if attempt == h.retryConfig.MaxRetries {
+			break
+		}
+
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+		h.metrics.MessagesRetried.Add(float64(len(tickets)))
+		time.Sleep(wait)
+	}
+
+	return attempts, lastErr
+}
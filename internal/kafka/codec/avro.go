@@ -0,0 +1,29 @@
+package codec
+
+import "github.com/hamba/avro/v2"
+
+// AvroCodec marshals using a fixed Avro schema, shared by producer and
+// consumer out of band (e.g. a schema registry or a checked-in .avsc file).
+type AvroCodec struct {
+	schema avro.Schema
+}
+
+func NewAvroCodec(schema string) (*AvroCodec, error) {
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &AvroCodec{schema: s}, nil
+}
+
+func (c *AvroCodec) Marshal(v interface{}) ([]byte, error) {
+	return avro.Marshal(c.schema, v)
+}
+
+func (c *AvroCodec) Unmarshal(data []byte, v interface{}) error {
+	return avro.Unmarshal(c.schema, data, v)
+}
+
+func (c *AvroCodec) ContentType() string {
+	return "application/avro"
+}
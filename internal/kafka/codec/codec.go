@@ -0,0 +1,12 @@
+// Package codec decouples the Kafka producer and consumer from any one
+// wire format, so a models.Ticket (or a generated protobuf/Avro type
+// standing in for it) can be marshalled differently per deployment
+// without touching internal/kafka itself.
+package codec
+
+// Codec marshals and unmarshals message payloads for the wire.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	ContentType() string
+}
@@ -0,0 +1,59 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sampleTicket struct {
+	ID      string `avro:"id"`
+	OrderID int64  `avro:"order_id"`
+}
+
+const sampleTicketSchema = `{
+	"type": "record",
+	"name": "Ticket",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "order_id", "type": "long"}
+	]
+}`
+
+func TestJSONCodec_RoundTrip(t *testing.T) {
+	codec := JSONCodec{}
+	in := sampleTicket{ID: "test-id", OrderID: 42}
+
+	data, err := codec.Marshal(in)
+	assert.NoError(t, err)
+
+	var out sampleTicket
+	assert.NoError(t, codec.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+	assert.Equal(t, "application/json", codec.ContentType())
+}
+
+func TestAvroCodec_RoundTrip(t *testing.T) {
+	codec, err := NewAvroCodec(sampleTicketSchema)
+	assert.NoError(t, err)
+
+	in := sampleTicket{ID: "test-id", OrderID: 42}
+
+	data, err := codec.Marshal(in)
+	assert.NoError(t, err)
+
+	var out sampleTicket
+	assert.NoError(t, codec.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+	assert.Equal(t, "application/avro", codec.ContentType())
+}
+
+func TestProtobufCodec_RejectsNonProtoMessage(t *testing.T) {
+	codec := ProtobufCodec{}
+
+	_, err := codec.Marshal(sampleTicket{ID: "test-id"})
+	assert.Error(t, err)
+
+	err = codec.Unmarshal([]byte{}, &sampleTicket{})
+	assert.Error(t, err)
+}
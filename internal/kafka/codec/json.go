@@ -0,0 +1,19 @@
+package codec
+
+import "encoding/json"
+
+// JSONCodec is the default Codec, matching the producer/consumer's
+// original behavior.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufCodec marshals types generated by protoc-gen-go. v must
+// implement proto.Message (e.g. a generated Ticket message standing in for
+// models.Ticket).
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("codec: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("codec: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (ProtobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
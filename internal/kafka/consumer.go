@@ -1,22 +1,67 @@
 package kafka
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"ticket-processor/internal/kafka/codec"
 	"ticket-processor/internal/metrics"
 	"ticket-processor/internal/models"
 
 	"github.com/IBM/sarama"
+	"github.com/cenkalti/backoff/v4"
 )
 
+// defaultDiscoveryInterval is how often Consume re-lists topics to pick up
+// new topics matching the configured patterns.
+const defaultDiscoveryInterval = 30 * time.Second
+
+// Consumer joins a consumer group subscribed to every broker topic
+// matching any of a set of literal names and/or regex patterns (e.g.
+// "^orders\\..*$"), periodically re-discovering the match set so newly
+// created topics are picked up without a restart.
 type Consumer struct {
 	consumer sarama.ConsumerGroup
-	topic    string
+	admin    sarama.ClusterAdmin
+	patterns []*regexp.Regexp
+	codec    codec.Codec
+
+	mu     sync.RWMutex
+	topics []string
+}
+
+// ConsumerConfig groups the tunables for NewConsumer: the wire codec and
+// whether Sarama should auto-commit offsets. AutoCommit should be disabled
+// when driving a BatchHandler, which commits itself on its own ticker.
+type ConsumerConfig struct {
+	Codec      codec.Codec
+	AutoCommit bool
 }
 
-func NewConsumer(brokers []string, groupID string, topic string) (*Consumer, error) {
+func NewConsumerConfig() *ConsumerConfig {
+	return &ConsumerConfig{
+		Codec:      codec.JSONCodec{},
+		AutoCommit: true,
+	}
+}
+
+// NewConsumer builds a Consumer subscribed to every topic matching one of
+// topicPatterns, which may mix literal topic names and regexes (e.g.
+// "ticket-events", "^orders\\..*$").
+func NewConsumer(brokers []string, groupID string, topicPatterns []string, consumerConfig *ConsumerConfig) (*Consumer, error) {
+	if consumerConfig == nil {
+		consumerConfig = NewConsumerConfig()
+	}
+	if consumerConfig.Codec == nil {
+		consumerConfig.Codec = codec.JSONCodec{}
+	}
+
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
 	config.Consumer.Offsets.Initial = sarama.OffsetOldest
@@ -26,38 +71,227 @@ func NewConsumer(brokers []string, groupID string, topic string) (*Consumer, err
 	config.Consumer.Group.Session.Timeout = 10 * time.Second
 	// Enable return errors
 	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.AutoCommit.Enable = consumerConfig.AutoCommit
 
 	group, err := sarama.NewConsumerGroup(brokers, groupID, config)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Consumer{
+	admin, err := sarama.NewClusterAdmin(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(topicPatterns))
+	for _, p := range topicPatterns {
+		// Anchor every pattern so a literal name matches only that topic,
+		// not every topic containing it.
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("kafka: invalid topic pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	c := &Consumer{
 		consumer: group,
-		topic:    topic,
-	}, nil
+		admin:    admin,
+		patterns: patterns,
+		codec:    consumerConfig.Codec,
+	}
+
+	topics, err := c.discoverTopics()
+	if err != nil {
+		return nil, err
+	}
+	c.topics = topics
+
+	return c, nil
+}
+
+// discoverTopics lists topics from the cluster and returns those matching
+// any configured pattern, sorted for stable comparison.
+func (c *Consumer) discoverTopics() ([]string, error) {
+	allTopics, err := c.admin.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for name := range allTopics {
+		for _, pattern := range c.patterns {
+			if pattern.MatchString(name) {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+// resettable is implemented by handlers whose Ready() channel must be
+// recreated before a second Setup/Cleanup cycle on the same instance (e.g.
+// Handler, BatchHandler). Consume calls Reset itself between internal
+// rejoins, since those never return control to the caller's own
+// Consume-then-Reset retry loop.
+type resettable interface {
+	Reset()
+}
+
+func sameTopics(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Consume joins the consumer group against the currently-discovered topic
+// set and dispatches claims to handler. Every discoveryInterval it
+// re-lists topics; the group session only gets torn down and rejoined when
+// the match set has actually changed, so a steady topic set keeps the same
+// session (and the same claims) running across ticks instead of forcing a
+// rebalance. Consume returns when ctx is cancelled, when topic discovery
+// fails, or when the underlying group session ends: with its error, or with
+// nil after a server-side rebalance.
+func (c *Consumer) Consume(ctx context.Context, handler sarama.ConsumerGroupHandler, discoveryInterval time.Duration) error {
+	if discoveryInterval <= 0 {
+		discoveryInterval = defaultDiscoveryInterval
+	}
+	ticker := time.NewTicker(discoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		c.mu.RLock()
+		topics := append([]string(nil), c.topics...)
+		c.mu.RUnlock()
+
+		sessionCtx, cancel := context.WithCancel(ctx)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- c.consumer.Consume(sessionCtx, topics, handler)
+		}()
+
+		rejoin := false
+		for !rejoin {
+			select {
+			case <-ctx.Done():
+				cancel()
+				<-errCh
+				return ctx.Err()
+			case err := <-errCh:
+				cancel()
+				return err
+			case <-ticker.C:
+				newTopics, err := c.discoverTopics()
+				if err != nil {
+					cancel()
+					<-errCh
+					return err
+				}
+				if sameTopics(topics, newTopics) {
+					continue
+				}
+				log.Printf("Topic set changed, rejoining consumer group: %v -> %v", topics, newTopics)
+				c.mu.Lock()
+				c.topics = newTopics
+				c.mu.Unlock()
+				cancel()
+				<-errCh
+				if r, ok := handler.(resettable); ok {
+					r.Reset()
+				}
+				rejoin = true
+			}
+		}
+	}
+}
+
+// Codec returns the wire codec this consumer was constructed with, so
+// callers can wire the same codec into a Handler.
+func (c *Consumer) Codec() codec.Codec {
+	return c.codec
+}
+
+func (c *Consumer) Close() error {
+	if err := c.admin.Close(); err != nil {
+		return err
+	}
+	return c.consumer.Close()
 }
 
 type Handler struct {
-	ready   chan bool
-	metrics *metrics.Metrics
-	wg      *sync.WaitGroup
+	ready       chan bool
+	metrics     *metrics.Metrics
+	wg          *sync.WaitGroup
+	dlq         *DLQProducer
+	retryConfig *RetryConfig
+	codec       codec.Codec
+	registry    *HandlerRegistry
 }
 
-func NewHandler(metrics *metrics.Metrics, wg *sync.WaitGroup) *Handler {
+// NewHandler builds a Handler. registry resolves the business processor to
+// invoke for each successfully-unmarshalled ticket by its message's topic;
+// a nil registry, or a topic with nothing registered, is a no-op. Errors
+// returned from a processor are DLQ'd immediately unless wrapped with
+// NewRetryableError, in which case they're retried in-process per
+// retryConfig before being DLQ'd.
+func NewHandler(m *metrics.Metrics, wg *sync.WaitGroup, dlq *DLQProducer, retryConfig *RetryConfig, c codec.Codec, registry *HandlerRegistry) *Handler {
+	if retryConfig == nil {
+		retryConfig = NewRetryConfig()
+	}
+	if c == nil {
+		c = codec.JSONCodec{}
+	}
 	return &Handler{
-		ready:   make(chan bool),
-		metrics: metrics,
-		wg:      wg,
+		ready:       make(chan bool),
+		metrics:     m,
+		wg:          wg,
+		dlq:         dlq,
+		retryConfig: retryConfig,
+		codec:       c,
+		registry:    registry,
 	}
 }
 
-func (h *Handler) Setup(sarama.ConsumerGroupSession) error {
+// Ready signals once Setup has run for the current session.
+func (h *Handler) Ready() <-chan bool {
+	return h.ready
+}
+
+// Reset replaces the ready channel, for callers that re-invoke Consume in a
+// loop across rebalances.
+func (h *Handler) Reset() {
+	h.ready = make(chan bool)
+}
+
+// Setup accounts for this session on wg (balanced by the matching Done in
+// Cleanup) so callers don't have to guess how many Setup/Cleanup cycles a
+// single Consume call will drive internally.
+func (h *Handler) Setup(session sarama.ConsumerGroupSession) error {
+	h.wg.Add(1)
+	for topic, partitions := range session.Claims() {
+		log.Printf("Assigned partitions: topic=%s partitions=%v", topic, partitions)
+	}
 	close(h.ready)
 	return nil
 }
 
-func (h *Handler) Cleanup(sarama.ConsumerGroupSession) error {
+func (h *Handler) Cleanup(session sarama.ConsumerGroupSession) error {
+	for topic, partitions := range session.Claims() {
+		log.Printf("Revoked partitions: topic=%s partitions=%v", topic, partitions)
+		for _, partition := range partitions {
+			// Reset the gauge so a revoked partition's last-seen lag doesn't
+			// linger and get mistaken for a live value after a rebalance.
+			h.metrics.ConsumerLag.DeleteLabelValues(topic, strconv.Itoa(int(partition)))
+		}
+	}
 	h.wg.Done()
 	return nil
 }
@@ -66,22 +300,114 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 	for message := range claim.Messages() {
 		startTime := time.Now()
 
-		var ticket models.Ticket
-		if err := json.Unmarshal(message.Value, &ticket); err != nil {
+		topicHandler, registered := h.topicHandler(message.Topic)
+		payload := topicHandler.New()
+		if err := h.codec.Unmarshal(message.Value, payload); err != nil {
 			h.metrics.ErrorsCount.Inc()
-			continue
+			if h.sendToDLQ(message, err, 1) {
+				session.MarkMessage(message, "")
+				continue
+			}
+			// Stop this claim: marking any later message would commit past
+			// this one.
+			log.Printf("Failed to DLQ unparsable message topic=%s partition=%d offset=%d, leaving offset uncommitted: %v", message.Topic, message.Partition, message.Offset, err)
+			return fmt.Errorf("kafka: failed to DLQ unparsable message at offset %d, halting partition: %w", message.Offset, err)
 		}
 
-		// Process ticket here
-		// Using OrderID as partition key to maintain ordering
-		h.metrics.MessagesConsumed.Inc()
-		h.metrics.ProcessingDuration.Observe(time.Since(startTime).Seconds())
+		shouldMark := true
+		if registered {
+			var procErr error
+			shouldMark, procErr = h.processWithRetry(session.Context(), payload, message, topicHandler.Process)
+			if procErr == nil {
+				h.metrics.MessagesConsumed.Inc()
+				h.metrics.ProcessingDuration.Observe(time.Since(startTime).Seconds())
+			}
+		}
 
-		// Calculate and update consumer lag
-		lag := time.Since(ticket.Timestamp).Seconds()
-		h.metrics.ConsumerLag.Set(lag)
+		// Calculate and update consumer lag from the high water mark rather
+		// than the ticket's own timestamp, which conflates producer clock
+		// skew with real lag.
+		lag := claim.HighWaterMarkOffset() - message.Offset
+		h.metrics.ConsumerLag.
+			WithLabelValues(message.Topic, strconv.Itoa(int(message.Partition))).
+			Set(float64(lag))
 
+		if !shouldMark {
+			log.Printf("Failed to DLQ message after processing error topic=%s partition=%d offset=%d, leaving offset uncommitted", message.Topic, message.Partition, message.Offset)
+			return fmt.Errorf("kafka: failed to DLQ message at offset %d, halting partition", message.Offset)
+		}
 		session.MarkMessage(message, "")
 	}
 	return nil
 }
+
+// topicHandler returns the TopicHandler registered for topic, and whether
+// one was found. Unregistered topics (or a nil registry) fall back to
+// decoding into a *models.Ticket with no processor, so lag/metrics
+// tracking still works for a topic nothing has claimed yet.
+func (h *Handler) topicHandler(topic string) (TopicHandler, bool) {
+	fallback := TopicHandler{New: func() interface{} { return new(models.Ticket) }}
+	if h.registry == nil {
+		return fallback, false
+	}
+	th, ok := h.registry.Get(topic)
+	if !ok {
+		return fallback, false
+	}
+	return th, true
+}
+
+// processWithRetry invokes process with the decoded payload, retrying
+// in-process up to retryConfig.MaxRetries attempts while the returned error
+// is retryable. A non-retryable error, or exhausting the retry budget,
+// sends the message to the dead-letter topic. It reports whether the
+// message was actually handled -- either processed successfully or
+// successfully DLQ'd -- so the caller knows it's safe to mark the offset;
+// if the DLQ publish itself failed, shouldMark is false and err is the
+// processing error that triggered it.
+func (h *Handler) processWithRetry(ctx context.Context, payload interface{}, message *sarama.ConsumerMessage, process func(ctx context.Context, payload interface{}) error) (shouldMark bool, err error) {
+	if process == nil {
+		return true, nil
+	}
+
+	b := h.retryConfig.CreateBackOff()
+	var lastErr error
+	var attempts int
+	for attempt := uint64(1); attempt <= h.retryConfig.MaxRetries; attempt++ {
+		attempts = int(attempt)
+		lastErr = process(ctx, payload)
+		if lastErr == nil {
+			return true, nil
+		}
+		if !IsRetryable(lastErr) {
+			return h.sendToDLQ(message, lastErr, attempts), lastErr
+		}
+		if attempt == h.retryConfig.MaxRetries {
+			break
+		}
+
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+		h.metrics.MessagesRetried.Inc()
+		time.Sleep(wait)
+	}
+
+	return h.sendToDLQ(message, lastErr, attempts), lastErr
+}
+
+// sendToDLQ republishes message to the dead-letter topic and reports
+// whether the publish succeeded, so callers don't commit an offset for a
+// message that was neither processed nor actually preserved in the DLQ.
+func (h *Handler) sendToDLQ(message *sarama.ConsumerMessage, procErr error, attempts int) bool {
+	if h.dlq == nil {
+		return false
+	}
+	if err := h.dlq.Send(message, procErr, attempts); err != nil {
+		h.metrics.ErrorsCount.Inc()
+		return false
+	}
+	h.metrics.MessagesDLQ.Inc()
+	return true
+}
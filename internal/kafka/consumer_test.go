@@ -0,0 +1,15 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameTopics(t *testing.T) {
+	assert.True(t, sameTopics(nil, nil))
+	assert.True(t, sameTopics([]string{"a", "b"}, []string{"a", "b"}))
+	assert.False(t, sameTopics([]string{"a", "b"}, []string{"a", "c"}))
+	assert.False(t, sameTopics([]string{"a"}, []string{"a", "b"}))
+	assert.False(t, sameTopics([]string{"a", "b"}, []string{"b", "a"}))
+}
@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// DLQTimestampHeader carries the time a message was published to the
+// dead-letter topic (RFC3339Nano, UTC), so a drainer can wait out only the
+// remaining portion of its delay instead of the delay in full.
+const DLQTimestampHeader = "dlq_timestamp"
+
+// DLQProducer republishes un-processable messages to a dead-letter topic,
+// preserving the original payload and recording why it couldn't be
+// processed so it can be inspected or redriven later.
+type DLQProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewDLQProducer(brokers []string, topic string) (*DLQProducer, error) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	config.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DLQProducer{
+		producer: producer,
+		topic:    topic,
+	}, nil
+}
+
+// Send republishes message to the dead-letter topic, carrying the original
+// topic/partition/offset, the processing error, and the number of attempts
+// made as headers.
+func (d *DLQProducer) Send(message *sarama.ConsumerMessage, procErr error, attempts int) error {
+	headers := []sarama.RecordHeader{
+		{Key: []byte("original_topic"), Value: []byte(message.Topic)},
+		{Key: []byte("original_partition"), Value: []byte(strconv.Itoa(int(message.Partition)))},
+		{Key: []byte("original_offset"), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		{Key: []byte("error"), Value: []byte(procErr.Error())},
+		{Key: []byte("attempt_count"), Value: []byte(strconv.Itoa(attempts))},
+		{Key: []byte(DLQTimestampHeader), Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   d.topic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+
+	_, _, err := d.producer.SendMessage(msg)
+	return err
+}
+
+func (d *DLQProducer) Close() error {
+	return d.producer.Close()
+}
@@ -0,0 +1,31 @@
+package kafka
+
+import "errors"
+
+// RetryableError marks a business-processing error as transient, telling
+// Handler.ConsumeClaim to retry the message in-process (via RetryConfig)
+// before giving up and routing it to the dead-letter topic. Errors that
+// are not wrapped this way are treated as non-retryable and DLQ'd
+// immediately.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// NewRetryableError wraps err so IsRetryable reports it as retryable.
+func NewRetryableError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err}
+}
+
+// IsRetryable reports whether err (or one it wraps) was produced by
+// NewRetryableError.
+func IsRetryable(err error) bool {
+	var re *RetryableError
+	return errors.As(err, &re)
+}
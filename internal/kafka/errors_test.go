@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	plain := errors.New("boom")
+	assert.False(t, IsRetryable(plain))
+	assert.True(t, IsRetryable(NewRetryableError(plain)))
+}
+
+func TestNewRetryableError_Nil(t *testing.T) {
+	assert.Nil(t, NewRetryableError(nil))
+}
+
+func TestRetryableError_Unwrap(t *testing.T) {
+	cause := errors.New("boom")
+	wrapped := NewRetryableError(cause)
+
+	assert.Equal(t, cause.Error(), wrapped.Error())
+	assert.ErrorIs(t, wrapped, cause)
+}
@@ -1,22 +1,89 @@
 package kafka
 
 import (
-	"encoding/json"
 	"strconv"
+	"time"
 
+	"ticket-processor/internal/kafka/codec"
 	"ticket-processor/internal/metrics"
 	"ticket-processor/internal/models"
 
 	"github.com/IBM/sarama"
+	"github.com/eapache/go-resiliency/breaker"
 )
 
+// ErrBreakerOpen is returned by SendTicket when the circuit breaker has
+// tripped and is refusing to let messages through.
+var ErrBreakerOpen = breaker.ErrBreakerOpen
+
+// breakerState mirrors the closed/half-open/open states of the underlying
+// breaker.Breaker so they can be surfaced on a Prometheus gauge.
+type breakerState int32
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// BreakerConfig controls the circuit breaker wrapped around SendTicket.
+// ErrorThreshold consecutive failures trip the breaker open; after Timeout
+// it half-opens and lets requests through as probes: SuccessThreshold
+// consecutive successes close it again, and any failure reopens it.
+type BreakerConfig struct {
+	ErrorThreshold   int
+	SuccessThreshold int
+	Timeout          time.Duration
+}
+
+func NewBreakerConfig() *BreakerConfig {
+	return &BreakerConfig{
+		ErrorThreshold:   5,
+		SuccessThreshold: 2,
+		Timeout:          10 * time.Second,
+	}
+}
+
+// ProducerConfig groups the tunables for NewProducer: the wire codec, the
+// Sarama compression settings, and the circuit breaker thresholds.
+type ProducerConfig struct {
+	Codec            codec.Codec
+	Compression      sarama.CompressionCodec
+	CompressionLevel int
+	Breaker          *BreakerConfig
+}
+
+// NewProducerConfig returns the defaults: JSON over the wire and Zstd
+// compression, which gives the best bandwidth savings for the larger
+// payloads (e.g. protobuf/Avro) this producer is also meant to carry.
+func NewProducerConfig() *ProducerConfig {
+	return &ProducerConfig{
+		Codec:            codec.JSONCodec{},
+		Compression:      sarama.CompressionZSTD,
+		CompressionLevel: sarama.CompressionLevelDefault,
+		Breaker:          NewBreakerConfig(),
+	}
+}
+
 type Producer struct {
 	producer sarama.SyncProducer
 	topic    string
 	metrics  *metrics.Metrics
+	breaker  *breaker.Breaker
+	codec    codec.Codec
 }
 
-func NewProducer(brokers []string, topic string) (*Producer, error) {
+func NewProducer(brokers []string, topic string, producerConfig *ProducerConfig, m *metrics.Metrics) (*Producer, error) {
+	if producerConfig == nil {
+		producerConfig = NewProducerConfig()
+	}
+	if producerConfig.Codec == nil {
+		producerConfig.Codec = codec.JSONCodec{}
+	}
+	if producerConfig.Breaker == nil {
+		producerConfig.Breaker = NewBreakerConfig()
+	}
+
 	config := sarama.NewConfig()
 	// Ensure synchronous producer for reliability
 	config.Producer.RequiredAcks = sarama.WaitForAll
@@ -26,6 +93,8 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 	config.Producer.Idempotent = true
 	// Use consistent partitioning for ordering
 	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Producer.Compression = producerConfig.Compression
+	config.Producer.CompressionLevel = producerConfig.CompressionLevel
 
 	producer, err := sarama.NewSyncProducer(brokers, config)
 	if err != nil {
@@ -35,24 +104,43 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 	return &Producer{
 		producer: producer,
 		topic:    topic,
+		metrics:  m,
+		breaker:  breaker.New(producerConfig.Breaker.ErrorThreshold, producerConfig.Breaker.SuccessThreshold, producerConfig.Breaker.Timeout),
+		codec:    producerConfig.Codec,
 	}, nil
 }
 
+// SendTicket marshals ticket with the producer's codec and publishes it,
+// keyed by OrderID to maintain per-order ordering. It's a thin convenience
+// wrapper around Send for the common case.
 func (p *Producer) SendTicket(ticket *models.Ticket) error {
-	data, err := json.Marshal(ticket)
+	return p.Send(strconv.FormatInt(ticket.OrderID, 10), ticket.Timestamp, ticket)
+}
+
+// Send marshals payload with the producer's codec and publishes it to the
+// configured topic through the circuit breaker, under the given partition
+// key and record timestamp. Unlike SendTicket, payload isn't required to be
+// a *models.Ticket: this is the entry point for a protobuf/Avro-generated
+// type swapped in via ProducerConfig.Codec.
+func (p *Producer) Send(key string, timestamp time.Time, payload interface{}) error {
+	data, err := p.codec.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	// Use OrderID as the partition key to maintain message ordering
 	msg := &sarama.ProducerMessage{
 		Topic:     p.topic,
-		Key:       sarama.StringEncoder(strconv.FormatInt(ticket.OrderID, 10)),
+		Key:       sarama.StringEncoder(key),
 		Value:     sarama.ByteEncoder(data),
-		Timestamp: ticket.Timestamp,
+		Timestamp: timestamp,
 	}
 
-	_, _, err = p.producer.SendMessage(msg)
+	err = p.breaker.Run(func() error {
+		_, _, sendErr := p.producer.SendMessage(msg)
+		return sendErr
+	})
+	p.updateBreakerGauge()
+
 	if err != nil {
 		p.metrics.ErrorsCount.Inc()
 		return err
@@ -62,6 +150,20 @@ func (p *Producer) SendTicket(ticket *models.Ticket) error {
 	return nil
 }
 
+// updateBreakerGauge surfaces the breaker's current state on the
+// BreakerState gauge, read straight from breaker.Breaker.GetState() rather
+// than inferred from a hand-tracked flag.
+func (p *Producer) updateBreakerGauge() {
+	switch p.breaker.GetState() {
+	case breaker.Open:
+		p.metrics.BreakerState.Set(float64(breakerOpen))
+	case breaker.HalfOpen:
+		p.metrics.BreakerState.Set(float64(breakerHalfOpen))
+	default:
+		p.metrics.BreakerState.Set(float64(breakerClosed))
+	}
+}
+
 func (p *Producer) Close() error {
 	return p.producer.Close()
 }
@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"testing"
+	"ticket-processor/internal/metrics"
 	"ticket-processor/internal/models"
 	"time"
 
@@ -14,7 +15,7 @@ func TestProducer_SendTicket(t *testing.T) {
 	topic := "test-topic"
 
 	// Create producer
-	producer, err := NewProducer(brokers, topic)
+	producer, err := NewProducer(brokers, topic, NewProducerConfig(), metrics.NewMetrics("test"))
 	assert.NoError(t, err)
 	defer producer.Close()
 
@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+// TopicHandler decodes and processes messages for one topic. New returns a
+// fresh, empty instance of that topic's payload type for the codec to
+// unmarshal into -- typically a *models.Ticket, but any type the configured
+// Codec can round-trip (e.g. a protobuf-generated message) works equally
+// well. Process is invoked with the decoded payload once unmarshalling
+// succeeds.
+type TopicHandler struct {
+	New     func() interface{}
+	Process func(ctx context.Context, payload interface{}) error
+}
+
+// HandlerRegistry dispatches messages to a per-topic TopicHandler, keyed by
+// the exact topic name, so a single consumer process can serve many
+// ticket-related streams without hard-coding a single payload type.
+type HandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]TopicHandler
+}
+
+func NewHandlerRegistry() *HandlerRegistry {
+	return &HandlerRegistry{
+		handlers: make(map[string]TopicHandler),
+	}
+}
+
+// Register sets the handler invoked for messages on topic, replacing any
+// handler previously registered for it.
+func (r *HandlerRegistry) Register(topic string, handler TopicHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[topic] = handler
+}
+
+// Get returns the handler registered for topic, and whether one was found.
+func (r *HandlerRegistry) Get(topic string) (TopicHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[topic]
+	return h, ok
+}
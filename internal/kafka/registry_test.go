@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerRegistry_GetUnregisteredTopic(t *testing.T) {
+	registry := NewHandlerRegistry()
+
+	_, ok := registry.Get("unknown-topic")
+	assert.False(t, ok)
+}
+
+func TestHandlerRegistry_RegisterAndGet(t *testing.T) {
+	registry := NewHandlerRegistry()
+
+	var processed interface{}
+	handler := TopicHandler{
+		New: func() interface{} { return new(string) },
+		Process: func(ctx context.Context, payload interface{}) error {
+			processed = payload
+			return nil
+		},
+	}
+	registry.Register("orders", handler)
+
+	got, ok := registry.Get("orders")
+	assert.True(t, ok)
+
+	assert.NoError(t, got.Process(context.Background(), "hello"))
+	assert.Equal(t, "hello", processed)
+}
+
+func TestHandlerRegistry_RegisterReplacesExisting(t *testing.T) {
+	registry := NewHandlerRegistry()
+	registry.Register("orders", TopicHandler{New: func() interface{} { return new(string) }})
+	registry.Register("orders", TopicHandler{New: func() interface{} { return new(int) }})
+
+	got, ok := registry.Get("orders")
+	assert.True(t, ok)
+	assert.IsType(t, new(int), got.New())
+}
@@ -10,7 +10,10 @@ type Metrics struct {
 	MessagesConsumed   prometheus.Counter
 	ProcessingDuration prometheus.Histogram
 	ErrorsCount        prometheus.Counter
-	ConsumerLag        prometheus.Gauge
+	ConsumerLag        *prometheus.GaugeVec
+	BreakerState       prometheus.Gauge
+	MessagesDLQ        prometheus.Counter
+	MessagesRetried    prometheus.Counter
 }
 
 func NewMetrics(namespace string) *Metrics {
@@ -36,10 +39,25 @@ func NewMetrics(namespace string) *Metrics {
 			Name:      "errors_total",
 			Help:      "The total number of errors",
 		}),
-		ConsumerLag: promauto.NewGauge(prometheus.GaugeOpts{
+		ConsumerLag: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "consumer_lag",
-			Help:      "The current consumer lag",
+			Help:      "The current consumer lag, in offsets behind the partition's high water mark",
+		}, []string{"topic", "partition"}),
+		BreakerState: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "producer_breaker_state",
+			Help:      "The state of the producer's circuit breaker (0=closed, 1=half-open, 2=open)",
+		}),
+		MessagesDLQ: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_dlq_total",
+			Help:      "The total number of messages routed to the dead-letter topic",
+		}),
+		MessagesRetried: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_retried_total",
+			Help:      "The total number of in-process retry attempts for failed messages",
 		}),
 	}
 }